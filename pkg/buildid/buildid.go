@@ -0,0 +1,245 @@
+// Package buildid reads ELF note records, most notably the Go and GNU
+// build IDs, without pulling in a full Go-binary parser. It is modeled on
+// the note-reading logic in the upstream Go toolchain's
+// cmd/internal/buildid package so that other parsers (C/C++ binary
+// scanning, container image layer analysis, ...) can look up arbitrary
+// notes, such as Fedora's package-provenance note or a GNU build ID used
+// for Linux distro lookups, without reimplementing the ELF plumbing.
+package buildid
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"io"
+
+	"golang.org/x/xerrors"
+
+	dio "github.com/deepfactor-io/go-dep-parser/pkg/io"
+)
+
+var (
+	goNoteName  = []byte("Go\x00\x00")
+	gnuNoteName = []byte("GNU\x00")
+
+	ErrNoteNotFound = xerrors.New("note not found")
+)
+
+const (
+	offsetToNoteData       = 16
+	offsetToNoteFields     = 12
+	sizeOfNoteNameAndValue = 4
+	elfGoBuildIDTag        = 4
+	gnuBuildIDTag          = 3
+
+	// progReadSize is how much of the file ReadELFNote buffers up front to
+	// decode the program header table without touching disk again. It
+	// mirrors the window getBuildID already read for format sniffing in
+	// pkg/golang/binary, which is where the Go build ID note normally
+	// lives.
+	progReadSize = 32 * 1024
+)
+
+// ReadGoBuildID returns the Go build ID embedded in an ELF binary's
+// .note.go.buildid note (name "Go\x00\x00", type 4).
+func ReadGoBuildID(r dio.ReadSeekerAt) (string, error) {
+	desc, err := ReadELFNote(r, string(goNoteName), elfGoBuildIDTag)
+	if err != nil {
+		return "", err
+	}
+	return string(desc), nil
+}
+
+// ReadGNUBuildID returns the GNU build ID embedded in an ELF binary, as
+// emitted by gccgo and most C/C++ toolchains (name "GNU\x00", type 3).
+func ReadGNUBuildID(r dio.ReadSeekerAt) (string, error) {
+	desc, err := ReadELFNote(r, string(gnuNoteName), gnuBuildIDTag)
+	if err != nil {
+		return "", err
+	}
+	return string(desc), nil
+}
+
+// ReadELFNote returns the descriptor of the first ELF note whose name and
+// type match, modeled on the upstream Go tooling's ReadELFNote. It scans
+// PT_NOTE program headers first, since that's where the linker normally
+// places notes, and falls back to walking SHT_NOTE sections when no
+// program header yields a match. The fallback covers binaries such as
+// those produced by the Solaris external linker, which assigns
+// .note.go.buildid to the text segment without emitting a PT_NOTE entry.
+// A PT_NOTE segment that sits past the buffered scan window is read
+// directly from r by the program header path.
+func ReadELFNote(r dio.ReadSeekerAt, name string, typ int32) ([]byte, error) {
+	if desc, err := readProgNotes(r, name, typ); err != nil {
+		return nil, err
+	} else if desc != nil {
+		return desc, nil
+	}
+
+	return readSectionNotes(r, name, typ)
+}
+
+/*
+ * readProgNotes is the fast path: it buffers only the first progReadSize
+ * bytes of the file and decodes the program header table out of that
+ * buffer, the same way the pre-refactor binary.readELF did, rather than
+ * letting elf.NewFile read the section headers and string table from
+ * disk. That's a waste of I/O when all we care about is the Prog list
+ * and the one ELF note. The ELF header is patched in the buffer to zero
+ * out shoff/shnum so elf.NewFile never looks past the buffer for them.
+ */
+func readProgNotes(r dio.ReadSeekerAt, name string, typ int32) ([]byte, error) {
+	data := make([]byte, progReadSize)
+	n, err := r.ReadAt(data, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	data = data[:n]
+
+	if len(data) < offsetToNoteFields || data[elf.EI_CLASS] != byte(elf.ELFCLASS64) {
+		// 32-bit ELF build IDs aren't worth the extra header layout; fall
+		// through to the section-based path, which elf.NewFile handles
+		// uniformly regardless of class.
+		return nil, nil
+	}
+	data[40], data[41], data[42], data[43] = 0, 0, 0, 0
+	data[44], data[45], data[46], data[47] = 0, 0, 0, 0
+	data[60] = 0
+	data[61] = 0
+
+	ef, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer ef.Close()
+
+	for _, p := range ef.Progs {
+		if p.Type != elf.PT_NOTE || p.Filesz < offsetToNoteData {
+			continue
+		}
+
+		var note []byte
+		if p.Off+p.Filesz <= uint64(len(data)) {
+			note = data[p.Off : p.Off+p.Filesz]
+		} else {
+			if _, err := r.Seek(int64(p.Off), io.SeekStart); err != nil {
+				return nil, err
+			}
+			note = make([]byte, p.Filesz)
+			if _, err := io.ReadFull(r, note); err != nil {
+				return nil, err
+			}
+		}
+
+		if desc := scanProgNotes(note, p.Align, ef.ByteOrder, name, typ); desc != nil {
+			return desc, nil
+		}
+	}
+
+	return nil, nil
+}
+
+/*
+ * readSectionNotes is the fallback path for ELF binaries whose note
+ * isn't found through a PT_NOTE program header, for example because the
+ * linker never emitted one (e.g. the Solaris external linker, which
+ * assigns .note.go.buildid to the text segment without a PT_NOTE entry)
+ * or because the binary is 32-bit, which readProgNotes skips.
+ * Unlike readProgNotes, it opens the file directly so
+ * elf.File can read the section headers and string table from disk.
+ */
+func readSectionNotes(r dio.ReadSeekerAt, name string, typ int32) ([]byte, error) {
+	ef, err := elf.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer ef.Close()
+
+	for _, sect := range ef.Sections {
+		if sect.Type != elf.SHT_NOTE {
+			continue
+		}
+		note, err := sect.Data()
+		if err != nil {
+			continue
+		}
+		if desc := scanNotes(note, ef.ByteOrder, name, typ); desc != nil {
+			return desc, nil
+		}
+	}
+
+	return nil, ErrNoteNotFound
+}
+
+// scanProgNotes walks the notes packed into a single PT_NOTE segment,
+// honoring the segment's alignment between entries the way the upstream
+// Go tooling does.
+func scanProgNotes(note []byte, align uint64, order binary.ByteOrder, name string, typ int32) []byte {
+	filesz := uint64(len(note))
+	off := uint64(0)
+	for filesz >= offsetToNoteData {
+		desc, notesz := matchNote(note, order, name, typ)
+		if desc != nil {
+			return desc
+		}
+		if filesz <= notesz {
+			break
+		}
+		off += notesz
+		if align != 0 {
+			alignedOff := (off + align - 1) &^ (align - 1)
+			notesz += alignedOff - off
+			off = alignedOff
+		}
+		filesz -= notesz
+		note = note[notesz:]
+	}
+	return nil
+}
+
+// scanNotes walks a flat blob of notes, such as the contents of an
+// SHT_NOTE section, where entries are simply 4-byte aligned one after
+// another.
+func scanNotes(note []byte, order binary.ByteOrder, name string, typ int32) []byte {
+	for len(note) >= offsetToNoteData {
+		desc, notesz := matchNote(note, order, name, typ)
+		if desc != nil {
+			return desc
+		}
+		if uint64(len(note)) <= notesz {
+			break
+		}
+		note = note[notesz:]
+	}
+	return nil
+}
+
+// matchNote decodes the note record at the front of note and, if its name
+// and type match, returns its descriptor. It also returns the (4-byte
+// aligned) size of the record so the caller can advance past it
+// regardless of whether it matched.
+//
+// name is compared byte-for-byte against the note's name field, not as a
+// NUL-terminated string: the Go linker's "Go\x00\x00" and "GNU\x00" notes
+// use a fixed 4-byte namesize that already includes their own padding,
+// so callers must pass the full padded literal (as ReadGoBuildID and
+// ReadGNUBuildID do) rather than a bare name for this to match.
+func matchNote(note []byte, order binary.ByteOrder, name string, typ int32) (desc []byte, notesz uint64) {
+	nameSize := order.Uint32(note)
+	valSize := order.Uint32(note[sizeOfNoteNameAndValue:])
+	tag := order.Uint32(note[8:])
+
+	alignedName := (nameSize + 3) &^ 3
+	alignedVal := (valSize + 3) &^ 3
+	notesz = uint64(offsetToNoteFields) + uint64(alignedName) + uint64(alignedVal)
+
+	if offsetToNoteFields+alignedName+alignedVal > uint32(len(note)) {
+		return nil, notesz
+	}
+
+	nname := note[offsetToNoteFields : offsetToNoteFields+alignedName]
+	if tag == uint32(typ) && nameSize == uint32(len(name)) && bytes.Equal(nname, []byte(name)) {
+		desc = note[offsetToNoteFields+alignedName : offsetToNoteFields+alignedName+valSize]
+	}
+	return desc, notesz
+}
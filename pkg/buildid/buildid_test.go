@@ -0,0 +1,194 @@
+package buildid
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+const (
+	elfHeaderSize = 64
+	elfPhdrSize   = 56
+)
+
+// buildNote encodes a single ELF note record in the {namesize, descsize,
+// type, name, desc} layout the Go linker and gccgo both use, 4-byte
+// aligning the name and desc fields.
+func buildNote(order binary.ByteOrder, name string, typ uint32, desc []byte) []byte {
+	var buf bytes.Buffer
+	write := func(v uint32) {
+		var b [4]byte
+		order.PutUint32(b[:], v)
+		buf.Write(b[:])
+	}
+	pad := func(n int) []byte {
+		return make([]byte, (n+3)&^3)
+	}
+
+	write(uint32(len(name)))
+	write(uint32(len(desc)))
+	write(typ)
+
+	nameBuf := pad(len(name))
+	copy(nameBuf, name)
+	buf.Write(nameBuf)
+
+	descBuf := pad(len(desc))
+	copy(descBuf, desc)
+	buf.Write(descBuf)
+
+	return buf.Bytes()
+}
+
+// buildELF assembles a minimal little-endian ELF64 executable with a
+// single PT_NOTE program header pointing at note.
+func buildELF(note []byte) []byte {
+	order := binary.LittleEndian
+	phoff := uint64(elfHeaderSize)
+	noteOff := uint64(elfHeaderSize + elfPhdrSize)
+
+	data := make([]byte, elfHeaderSize+elfPhdrSize)
+	data = append(data, note...)
+
+	phdr := data[elfHeaderSize : elfHeaderSize+elfPhdrSize]
+	order.PutUint32(phdr[0:], 4) // p_type = PT_NOTE
+	order.PutUint32(phdr[4:], 0) // p_flags
+	order.PutUint64(phdr[8:], noteOff)
+	order.PutUint64(phdr[16:], 0) // p_vaddr
+	order.PutUint64(phdr[24:], 0) // p_paddr
+	order.PutUint64(phdr[32:], uint64(len(note)))
+	order.PutUint64(phdr[40:], uint64(len(note)))
+	order.PutUint64(phdr[48:], 4) // p_align
+
+	data[0], data[1], data[2], data[3] = 0x7f, 'E', 'L', 'F'
+	data[4] = 2 // ELFCLASS64
+	data[5] = 1 // ELFDATA2LSB
+	data[6] = 1 // EV_CURRENT
+
+	order.PutUint16(data[16:], 2)    // e_type = ET_EXEC
+	order.PutUint16(data[18:], 0x3e) // e_machine = EM_X86_64
+	order.PutUint32(data[20:], 1)    // e_version
+	order.PutUint64(data[24:], 0)    // e_entry
+	order.PutUint64(data[32:], phoff)
+	order.PutUint64(data[40:], 0) // e_shoff
+	order.PutUint32(data[48:], 0) // e_flags
+	order.PutUint16(data[52:], elfHeaderSize)
+	order.PutUint16(data[54:], elfPhdrSize)
+	order.PutUint16(data[56:], 1) // e_phnum
+	order.PutUint16(data[58:], 0) // e_shentsize
+	order.PutUint16(data[60:], 0) // e_shnum
+	order.PutUint16(data[62:], 0) // e_shstrndx
+
+	return data
+}
+
+// buildELFSectionOnly assembles a minimal little-endian ELF64 binary with
+// no program headers at all and a single SHT_NOTE section holding note,
+// mirroring the Solaris external-linker case where .note.go.buildid is
+// reachable only through the section table.
+func buildELFSectionOnly(note []byte) []byte {
+	const shdrSize = 64
+	order := binary.LittleEndian
+
+	// Section header string table: empty name (index 0), then the two
+	// section names, each NUL-terminated.
+	shstrtab := []byte{0}
+	noteNameOff := uint32(len(shstrtab))
+	shstrtab = append(shstrtab, append([]byte(".note.go.buildid"), 0)...)
+	shstrtabNameOff := uint32(len(shstrtab))
+	shstrtab = append(shstrtab, append([]byte(".shstrtab"), 0)...)
+
+	noteOff := uint64(elfHeaderSize)
+	shstrtabOff := noteOff + uint64(len(note))
+	shOff := shstrtabOff + uint64(len(shstrtab))
+
+	data := make([]byte, elfHeaderSize)
+	data = append(data, note...)
+	data = append(data, shstrtab...)
+	data = append(data, make([]byte, shdrSize*3)...) // null, note, shstrtab
+
+	shdrs := data[shOff:]
+	nullShdr := shdrs[0*shdrSize : 1*shdrSize]
+	noteShdr := shdrs[1*shdrSize : 2*shdrSize]
+	strShdr := shdrs[2*shdrSize : 3*shdrSize]
+	_ = nullShdr // left entirely zeroed
+
+	order.PutUint32(noteShdr[0:], noteNameOff)
+	order.PutUint32(noteShdr[4:], 7) // sh_type = SHT_NOTE
+	order.PutUint64(noteShdr[24:], noteOff)
+	order.PutUint64(noteShdr[32:], uint64(len(note)))
+	order.PutUint64(noteShdr[48:], 4) // sh_addralign
+
+	order.PutUint32(strShdr[0:], shstrtabNameOff)
+	order.PutUint32(strShdr[4:], 3) // sh_type = SHT_STRTAB
+	order.PutUint64(strShdr[24:], shstrtabOff)
+	order.PutUint64(strShdr[32:], uint64(len(shstrtab)))
+	order.PutUint64(strShdr[48:], 1) // sh_addralign
+
+	data[0], data[1], data[2], data[3] = 0x7f, 'E', 'L', 'F'
+	data[4] = 2 // ELFCLASS64
+	data[5] = 1 // ELFDATA2LSB
+	data[6] = 1 // EV_CURRENT
+
+	order.PutUint16(data[16:], 2)    // e_type = ET_EXEC
+	order.PutUint16(data[18:], 0x3e) // e_machine = EM_X86_64
+	order.PutUint32(data[20:], 1)    // e_version
+	order.PutUint64(data[24:], 0)    // e_entry
+	order.PutUint64(data[32:], 0)    // e_phoff
+	order.PutUint64(data[40:], shOff)
+	order.PutUint32(data[48:], 0) // e_flags
+	order.PutUint16(data[52:], elfHeaderSize)
+	order.PutUint16(data[54:], 0) // e_phentsize
+	order.PutUint16(data[56:], 0) // e_phnum
+	order.PutUint16(data[58:], shdrSize)
+	order.PutUint16(data[60:], 3) // e_shnum: null, note, shstrtab
+	order.PutUint16(data[62:], 2) // e_shstrndx
+
+	return data
+}
+
+func TestReadGoBuildID_SHTNoteFallback(t *testing.T) {
+	const wantID = "solaris-linker-placed-this-note"
+	note := buildNote(binary.LittleEndian, "Go\x00\x00", elfGoBuildIDTag, []byte(wantID))
+	data := buildELFSectionOnly(note)
+
+	got, err := ReadGoBuildID(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadGoBuildID (SHT_NOTE fallback): %v", err)
+	}
+	if got != wantID {
+		t.Fatalf("ReadGoBuildID = %q, want %q", got, wantID)
+	}
+}
+
+func TestReadGoBuildID_PTNote(t *testing.T) {
+	const wantID = "ZNqK-qDUuHAoyUk1D1Pv/abcdefghijklmnopqrst"
+	note := buildNote(binary.LittleEndian, "Go\x00\x00", elfGoBuildIDTag, []byte(wantID))
+	data := buildELF(note)
+
+	got, err := ReadGoBuildID(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadGoBuildID: %v", err)
+	}
+	if got != wantID {
+		t.Fatalf("ReadGoBuildID = %q, want %q", got, wantID)
+	}
+}
+
+func TestReadGNUBuildID_PTNote(t *testing.T) {
+	const wantID = "deadbeefcafef00d"
+	note := buildNote(binary.LittleEndian, "GNU\x00", gnuBuildIDTag, []byte(wantID))
+	data := buildELF(note)
+
+	got, err := ReadGNUBuildID(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadGNUBuildID: %v", err)
+	}
+	if got != wantID {
+		t.Fatalf("ReadGNUBuildID = %q, want %q", got, wantID)
+	}
+
+	if _, err := ReadGoBuildID(bytes.NewReader(data)); err != ErrNoteNotFound {
+		t.Fatalf("ReadGoBuildID on a GNU-only binary = %v, want ErrNoteNotFound", err)
+	}
+}
@@ -3,13 +3,15 @@ package binary
 import (
 	"bytes"
 	"debug/buildinfo"
-	"debug/elf"
+	"debug/macho"
+	"debug/pe"
 	"fmt"
 	"io"
 	"strings"
 
 	"golang.org/x/xerrors"
 
+	"github.com/deepfactor-io/go-dep-parser/pkg/buildid"
 	dio "github.com/deepfactor-io/go-dep-parser/pkg/io"
 	"github.com/deepfactor-io/go-dep-parser/pkg/types"
 )
@@ -19,18 +21,17 @@ var (
 	ErrNonGoBinary         = xerrors.New("non go binary")
 	readSize               = 32 * 1024
 	elfPrefix              = []byte("\x7fELF")
-	elfGoNote              = []byte("Go\x00\x00")
-	elfGNUNote             = []byte("GNU\x00")
+	machoMagic32           = []byte{0xfe, 0xed, 0xfa, 0xce}
+	machoMagic64           = []byte{0xfe, 0xed, 0xfa, 0xcf}
+	machoCigam32           = []byte{0xce, 0xfa, 0xed, 0xfe}
+	machoCigam64           = []byte{0xcf, 0xfa, 0xed, 0xfe}
+	peMagic                = []byte("MZ")
+	rawBuildIDPrefix       = []byte("\xff Go build ID: \"")
+	rawBuildIDSuffix       = []byte("\"\n \xff")
 	errProgramNotSupported = fmt.Errorf("Program not supported")
 	errBuildIDNotFound     = fmt.Errorf("Go BuildID not found")
 )
 
-const offsetToNoteData = 16
-const offsetToNoteFields = 12
-const sizeOfNoteNameAndValue = 4
-const elfGoBuildIDTag = 4
-const gnuBuildIDTag = 3
-
 // convertError detects buildinfo.errUnrecognizedFormat and convert to
 // ErrUnrecognizedExe and convert buildinfo.errNotGoExe to ErrNonGoBinary
 func convertError(err error) error {
@@ -95,109 +96,112 @@ func (p *Parser) Parse(r dio.ReadSeekerAt) ([]types.Library, []types.Dependency,
 	return libs, nil, nil
 }
 
-/**
- * The Go build ID is stored in a note described by an ELF PT_NOTE prog
- * header. The caller has already opened filename, to get f, and read
- * at least 4 kB out, in data.
- */
-func readELF(r dio.ReadSeekerAt, data []byte) (buildid string, err error) {
-	/*
-	 * Assume the note content is in the data, already read.
-	 * Rewrite the ELF header to set shoff and shnum to 0, so that we can pass
-	 * the data to elf.NewFile and it will decode the Prog list but not
-	 * try to read the section headers and the string table from disk.
-	 * That's a waste of I/O when all we care about is the Prog list
-	 * and the one ELF note.
-	 * These specific bytes are at offsets 40-43, 44-47, 60, and 61 in the data
-	 * slice.
-	 */
-	switch elf.Class(data[elf.EI_CLASS]) {
-	case elf.ELFCLASS32:
-		return "", errProgramNotSupported
-	case elf.ELFCLASS64:
-		data[40], data[41], data[42], data[43] = 0, 0, 0, 0
-		data[44], data[45], data[46], data[47] = 0, 0, 0, 0
-		data[60] = 0
-		data[61] = 0
-	}
-	ef, err := elf.NewFile(bytes.NewReader(data))
-	if err != nil {
+// readELF delegates to pkg/buildid, which holds the shared ELF PT_NOTE/
+// SHT_NOTE note-reading logic, and falls back to a GNU build ID (as
+// emitted by gccgo) when no Go note is present.
+func readELF(r dio.ReadSeekerAt) (string, error) {
+	id, err := buildid.ReadGoBuildID(r)
+	if err == nil {
+		return id, nil
+	}
+	if err != buildid.ErrNoteNotFound {
 		return "", err
 	}
-	var gnu string
-	for _, p := range ef.Progs {
-		if p.Type != elf.PT_NOTE || p.Filesz < offsetToNoteData {
-			continue
-		}
-		var note []byte
-		if p.Off+p.Filesz < uint64(len(data)) {
-			note = data[p.Off : p.Off+p.Filesz]
-		} else {
-			/*
-			 * For some linkers, such as the Solaris linker,
-			 * the buildid may not be found in data (which
-			 * likely contains the first 16kB of the file)
-			 * or even the first few megabytes of the file
-			 * due to differences in note segment placement;
-			 * in that case, extract the note data manually.
-			 */
-			_, err = r.Seek(int64(p.Off), io.SeekStart)
-			if err != nil {
-				return "", err
-			}
-			note = make([]byte, p.Filesz)
-			_, err = io.ReadFull(r, note)
-			if err != nil {
-				return "", err
-			}
-		}
-		filesz := p.Filesz
-		off := p.Off
-		for filesz >= offsetToNoteData {
-			nameSize := ef.ByteOrder.Uint32(note)
-			valSize := ef.ByteOrder.Uint32(note[sizeOfNoteNameAndValue:])
-			tag := ef.ByteOrder.Uint32(note[8:])
-			nname := note[offsetToNoteFields : offsetToNoteFields+sizeOfNoteNameAndValue]
-			if nameSize == sizeOfNoteNameAndValue && offsetToNoteData+valSize <= uint32(len(note)) &&
-				tag == elfGoBuildIDTag && bytes.Equal(nname, elfGoNote) {
-				return string(note[offsetToNoteData : offsetToNoteData+valSize]), nil
-			}
-			if nameSize == sizeOfNoteNameAndValue && offsetToNoteData+valSize <= uint32(len(note)) &&
-				tag == gnuBuildIDTag && bytes.Equal(nname, elfGNUNote) {
-				gnu = string(note[offsetToNoteData : offsetToNoteData+valSize])
-			}
-			nameSize = (nameSize + 3) &^ 3
-			valSize = (valSize + 3) &^ 3
-			notesz := uint64(offsetToNoteFields + nameSize + valSize)
-			if filesz <= notesz {
-				break
-			}
-			off += notesz
-			align := p.Align
-			if align != 0 {
-				alignedOff := (off + align - 1) &^ (align - 1)
-				notesz += alignedOff - off
-				off = alignedOff
-			}
-			filesz -= notesz
-			note = note[notesz:]
-		}
+
+	id, err = buildid.ReadGNUBuildID(r)
+	if err == nil {
+		return id, nil
 	}
-	/*
-	 * If we didn't find a Go note, use a GNU note if available.
-	 * This is what gccgo uses.
-	 */
-	if gnu != "" {
-		return gnu, nil
+	if err != buildid.ErrNoteNotFound {
+		return "", err
 	}
+
 	/* No note. Treat as successful but build ID empty. */
 	return "", nil
 }
 
+/*
+ * Mach-O has no equivalent of an ELF note, so the Go linker instead
+ * embeds the build ID as a literal marker, `\xff Go build ID: "<id>"\n \xff`,
+ * directly in the text segment. This mirrors the raw-build-ID fallback in
+ * the upstream Go tooling, which is also what it uses for every binary
+ * format besides ELF. We open the file with debug/macho to locate the
+ * __text section and scan its first bytes for the marker.
+ */
+func readMacho(r dio.ReadSeekerAt) (buildid string, err error) {
+	f, err := macho.NewFile(r)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sect := f.Section("__text")
+	if sect == nil {
+		return "", errBuildIDNotFound
+	}
+
+	size := sect.Size
+	if size > uint64(readSize) {
+		size = uint64(readSize)
+	}
+	data := make([]byte, size)
+	if _, err := sect.ReadAt(data, 0); err != nil && err != io.EOF {
+		return "", err
+	}
+
+	return readRawBuildID(data)
+}
+
+/*
+ * PE binaries carry the same raw build ID marker as Mach-O, embedded in
+ * the .text section rather than in a dedicated note section (PE has no
+ * note concept either).
+ */
+func readPE(r dio.ReadSeekerAt) (buildid string, err error) {
+	f, err := pe.NewFile(r)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sect := f.Section(".text")
+	if sect == nil {
+		return "", errBuildIDNotFound
+	}
+
+	data, err := sect.Data()
+	if err != nil {
+		return "", err
+	}
+	if uint64(len(data)) > uint64(readSize) {
+		data = data[:readSize]
+	}
+
+	return readRawBuildID(data)
+}
+
+// readRawBuildID scans data for the `\xff Go build ID: "<id>"\n \xff`
+// marker the Go linker writes into the text segment of binary formats,
+// such as Mach-O and PE, that have no native note mechanism.
+func readRawBuildID(data []byte) (string, error) {
+	i := bytes.Index(data, rawBuildIDPrefix)
+	if i < 0 {
+		return "", errBuildIDNotFound
+	}
+	data = data[i+len(rawBuildIDPrefix):]
+
+	j := bytes.Index(data, rawBuildIDSuffix)
+	if j < 0 {
+		return "", errBuildIDNotFound
+	}
+
+	return string(data[:j]), nil
+}
+
 func getBuildID(r dio.ReadSeekerAt) (id string, err error) {
 	/*
 	 * Adding some sanity check
-	 * we only support elf header
+	 * we only support elf, mach-o and pe headers
 	 */
 
 	buf := make([]byte, 8)
@@ -216,8 +220,14 @@ func getBuildID(r dio.ReadSeekerAt) (id string, err error) {
 		if err != nil {
 			return "", err
 		}
-		if bytes.HasPrefix(data, elfPrefix) {
-			return readELF(r, data)
+		switch {
+		case bytes.HasPrefix(data, elfPrefix):
+			return readELF(r)
+		case bytes.HasPrefix(data, machoMagic32), bytes.HasPrefix(data, machoMagic64),
+			bytes.HasPrefix(data, machoCigam32), bytes.HasPrefix(data, machoCigam64):
+			return readMacho(r)
+		case bytes.HasPrefix(data, peMagic):
+			return readPE(r)
 		}
 	}
 	return "", errProgramNotSupported
@@ -0,0 +1,187 @@
+package binary
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestReadRawBuildID(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "marker present",
+			data: append([]byte("\x90\x90\x90"), []byte("\xff Go build ID: \"ZNqK-qDUuHAoyUk1D1Pv/abcdefghijklmnopqrst\"\n \xff...")...),
+			want: "ZNqK-qDUuHAoyUk1D1Pv/abcdefghijklmnopqrst",
+		},
+		{
+			name:    "no marker",
+			data:    []byte("\x90\x90\x90\x90"),
+			wantErr: true,
+		},
+		{
+			name:    "unterminated marker",
+			data:    []byte("\xff Go build ID: \"truncated"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readRawBuildID(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("readRawBuildID() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readRawBuildID() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("readRawBuildID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// buildMachO64 assembles a minimal big-endian Mach-O 64-bit binary with a
+// single __TEXT,__text section holding text, covering only the header
+// fields debug/macho.NewFile and f.Section("__text") actually read.
+func buildMachO64(text []byte) []byte {
+	const (
+		machHeaderSize = 32
+		segCmdSize     = 72
+		sectSize       = 80
+	)
+	order := binary.BigEndian
+
+	loadCmdsOff := uint64(machHeaderSize)
+	textOff := loadCmdsOff + segCmdSize + sectSize
+
+	data := make([]byte, textOff+uint64(len(text)))
+	copy(data[textOff:], text)
+
+	copy(data[0:4], []byte{0xfe, 0xed, 0xfa, 0xcf}) // magic = MH_MAGIC_64
+	order.PutUint32(data[4:], 0x01000007)           // cputype = CPU_TYPE_X86_64
+	order.PutUint32(data[8:], 3)                    // cpusubtype
+	order.PutUint32(data[12:], 2)                   // filetype = MH_EXECUTE
+	order.PutUint32(data[16:], 1)                   // ncmds
+	order.PutUint32(data[20:], segCmdSize+sectSize) // sizeofcmds
+	order.PutUint32(data[24:], 0)                   // flags
+	order.PutUint32(data[28:], 0)                   // reserved
+
+	seg := data[loadCmdsOff:]
+	order.PutUint32(seg[0:], 0x19) // cmd = LC_SEGMENT_64
+	order.PutUint32(seg[4:], segCmdSize+sectSize)
+	copy(seg[8:24], "__TEXT")
+	order.PutUint64(seg[24:], 0)                 // vmaddr
+	order.PutUint64(seg[32:], uint64(len(text)))  // vmsize
+	order.PutUint64(seg[40:], textOff)            // fileoff
+	order.PutUint64(seg[48:], uint64(len(text)))  // filesize
+	order.PutUint32(seg[56:], 7)                  // maxprot
+	order.PutUint32(seg[60:], 7)                  // initprot
+	order.PutUint32(seg[64:], 1)                  // nsects
+	order.PutUint32(seg[68:], 0)                  // flags
+
+	sect := data[loadCmdsOff+segCmdSize:]
+	copy(sect[0:16], "__text")
+	copy(sect[16:32], "__TEXT")
+	order.PutUint64(sect[32:], 0)                // addr
+	order.PutUint64(sect[40:], uint64(len(text))) // size
+	order.PutUint32(sect[48:], uint32(textOff))  // offset
+	order.PutUint32(sect[52:], 0)                // align
+	order.PutUint32(sect[56:], 0)                // reloff
+	order.PutUint32(sect[60:], 0)                // nreloc
+	order.PutUint32(sect[64:], 0)                // flags
+	order.PutUint32(sect[68:], 0)                // reserved1
+	order.PutUint32(sect[72:], 0)                // reserved2
+	order.PutUint32(sect[76:], 0)                // reserved3
+
+	return data
+}
+
+// buildPE assembles a minimal little-endian PE image with a single .text
+// section holding text, covering only the header fields debug/pe.NewFile
+// and f.Section(".text") actually read. The optional header is omitted
+// (SizeOfOptionalHeader=0) since nothing here needs it.
+func buildPE(text []byte) []byte {
+	const (
+		dosHeaderSize  = 0x40
+		fileHeaderSize = 20
+		sectHeaderSize = 40
+	)
+	order := binary.LittleEndian
+
+	peOff := uint32(dosHeaderSize)
+	sectHeaderOff := peOff + 4 + fileHeaderSize
+	textOff := sectHeaderOff + sectHeaderSize
+
+	data := make([]byte, textOff+uint32(len(text)))
+	copy(data[textOff:], text)
+
+	data[0], data[1] = 'M', 'Z'
+	order.PutUint32(data[0x3c:], peOff)
+
+	copy(data[peOff:], []byte("PE\x00\x00"))
+
+	fh := data[peOff+4:]
+	order.PutUint16(fh[0:], 0x8664)   // Machine = IMAGE_FILE_MACHINE_AMD64
+	order.PutUint16(fh[2:], 1)        // NumberOfSections
+	order.PutUint32(fh[4:], 0)        // TimeDateStamp
+	order.PutUint32(fh[8:], 0)        // PointerToSymbolTable
+	order.PutUint32(fh[12:], 0)       // NumberOfSymbols
+	order.PutUint16(fh[16:], 0)       // SizeOfOptionalHeader
+	order.PutUint16(fh[18:], 0x0002)  // Characteristics = IMAGE_FILE_EXECUTABLE_IMAGE
+
+	sh := data[sectHeaderOff:]
+	copy(sh[0:8], ".text")
+	order.PutUint32(sh[8:], uint32(len(text)))  // VirtualSize
+	order.PutUint32(sh[12:], 0)                 // VirtualAddress
+	order.PutUint32(sh[16:], uint32(len(text))) // SizeOfRawData
+	order.PutUint32(sh[20:], textOff)           // PointerToRawData
+	order.PutUint32(sh[24:], 0)                 // PointerToRelocations
+	order.PutUint32(sh[28:], 0)                 // PointerToLineNumbers
+	order.PutUint16(sh[32:], 0)                 // NumberOfRelocations
+	order.PutUint16(sh[34:], 0)                 // NumberOfLineNumbers
+	order.PutUint32(sh[36:], 0)                 // Characteristics
+
+	return data
+}
+
+// buildIDMarker wraps id in the raw marker the Go linker writes into the
+// text segment of binary formats, such as Mach-O and PE, that have no
+// native note mechanism.
+func buildIDMarker(id string) []byte {
+	return []byte("\xff Go build ID: \"" + id + "\"\n \xff")
+}
+
+func TestGetBuildID_MachO(t *testing.T) {
+	const wantID = "darwin-amd64-build-id"
+	data := buildMachO64(buildIDMarker(wantID))
+
+	got, err := getBuildID(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("getBuildID (Mach-O): %v", err)
+	}
+	if got != wantID {
+		t.Fatalf("getBuildID (Mach-O) = %q, want %q", got, wantID)
+	}
+}
+
+func TestGetBuildID_PE(t *testing.T) {
+	const wantID = "windows-amd64-build-id"
+	data := buildPE(buildIDMarker(wantID))
+
+	got, err := getBuildID(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("getBuildID (PE): %v", err)
+	}
+	if got != wantID {
+		t.Fatalf("getBuildID (PE) = %q, want %q", got, wantID)
+	}
+}
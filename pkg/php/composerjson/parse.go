@@ -4,20 +4,49 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"sort"
+	"strings"
 
 	"golang.org/x/exp/maps"
 	"golang.org/x/xerrors"
 
 	dio "github.com/deepfactor-io/go-dep-parser/pkg/io"
 	"github.com/deepfactor-io/go-dep-parser/pkg/types"
+	"github.com/deepfactor-io/go-dep-parser/pkg/utils"
 )
 
+// platformPrefix namespaces the ID of a platform package (PHP itself, a
+// PHP extension or library, or the Composer plugin/runtime API) so
+// downstream consumers can tell it apart from an ordinary Packagist
+// package and enforce its version constraint separately.
+const platformPrefix = "platform:"
+
 type composerJSON struct {
+	Name       string            `json:"name"`
+	Version    string            `json:"version"`
+	License    json.RawMessage   `json:"license"`
 	Require    map[string]string `json:"require"`
 	RequireDev map[string]string `json:"require-dev"`
 }
 
+// composerManifest implements types.PackageManifest for a composer.json
+// file, so Composer participates in the manifest-level license discovery
+// flow alongside the other PackageManifestParser implementations.
+type composerManifest struct {
+	name    string
+	version string
+	license string
+}
+
+func (m *composerManifest) PackageID() string {
+	return utils.PackageID(m.name, m.version)
+}
+
+func (m *composerManifest) DeclaredLicense() string {
+	return m.license
+}
+
 type Parser struct{}
 
 func NewParser() types.Parser {
@@ -25,20 +54,16 @@ func NewParser() types.Parser {
 }
 
 func (p *Parser) Parse(r dio.ReadSeekerAt) ([]types.Library, []types.Dependency, error) {
-	var cJSON composerJSON
-	input, err := io.ReadAll(r)
+	cJSON, err := decodeComposerJSON(r)
 	if err != nil {
-		return nil, nil, xerrors.Errorf("read error: %w", err)
-	}
-	if err = json.Unmarshal(input, &cJSON); err != nil {
-		return nil, nil, xerrors.Errorf("unmarshal error: %w", err)
+		return nil, nil, err
 	}
 
 	libs := map[string]types.Library{}
 
 	for pkg, ver := range cJSON.Require {
 		lib := types.Library{
-			ID:       pkg,
+			ID:       packageID(pkg),
 			Name:     pkg,
 			Version:  ver,
 			Indirect: false,
@@ -49,7 +74,7 @@ func (p *Parser) Parse(r dio.ReadSeekerAt) ([]types.Library, []types.Dependency,
 
 	for pkg, ver := range cJSON.RequireDev {
 		lib := types.Library{
-			ID:       pkg,
+			ID:       packageID(pkg),
 			Name:     pkg,
 			Version:  ver,
 			Indirect: false,
@@ -62,3 +87,89 @@ func (p *Parser) Parse(r dio.ReadSeekerAt) ([]types.Library, []types.Dependency,
 
 	return libSlice, []types.Dependency{}, nil
 }
+
+// ParseManifest decodes the composer.json at path and returns its
+// package identity and declared license, for the manifest-level license
+// discovery flow.
+func (p *Parser) ParseManifest(fsys fs.FS, path string) (types.PackageManifest, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, xerrors.Errorf("file open error: %w", err)
+	}
+	defer f.Close()
+
+	input, err := io.ReadAll(f)
+	if err != nil {
+		return nil, xerrors.Errorf("read error: %w", err)
+	}
+
+	var cJSON composerJSON
+	if err = json.Unmarshal(input, &cJSON); err != nil {
+		return nil, xerrors.Errorf("unmarshal error: %w", err)
+	}
+
+	license, err := decodeLicense(cJSON.License)
+	if err != nil {
+		return nil, xerrors.Errorf("license unmarshal error: %w", err)
+	}
+
+	return &composerManifest{
+		name:    cJSON.Name,
+		version: cJSON.Version,
+		license: license,
+	}, nil
+}
+
+func decodeComposerJSON(r dio.ReadSeekerAt) (composerJSON, error) {
+	var cJSON composerJSON
+	input, err := io.ReadAll(r)
+	if err != nil {
+		return cJSON, xerrors.Errorf("read error: %w", err)
+	}
+	if err = json.Unmarshal(input, &cJSON); err != nil {
+		return cJSON, xerrors.Errorf("unmarshal error: %w", err)
+	}
+	return cJSON, nil
+}
+
+// decodeLicense accepts composer's two license shapes: a single SPDX
+// expression string, or an array of them meaning the package is
+// dual/multi-licensed under any of them (composer's convention is "OR",
+// never "AND"). The array is joined into a single SPDX expression.
+func decodeLicense(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single, nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(raw, &multiple); err != nil {
+		return "", xerrors.Errorf("unmarshal error: %w", err)
+	}
+	return strings.Join(multiple, " OR "), nil
+}
+
+// packageID returns the Library ID for a required package, namespacing
+// platform packages (PHP, PHP extensions/libraries, the Composer
+// plugin/runtime API) so they aren't silently treated as ordinary
+// Packagist packages downstream.
+func packageID(pkg string) string {
+	if isPlatformPackage(pkg) {
+		return platformPrefix + pkg
+	}
+	return pkg
+}
+
+// isPlatformPackage reports whether pkg is one of composer's "platform
+// packages": https://getcomposer.org/doc/01-basic-usage.md#platform-packages
+func isPlatformPackage(pkg string) bool {
+	switch pkg {
+	case "php", "php-64bit", "hhvm", "composer-plugin-api", "composer-runtime-api":
+		return true
+	}
+	return strings.HasPrefix(pkg, "ext-") || strings.HasPrefix(pkg, "lib-")
+}
@@ -0,0 +1,131 @@
+package composerjson
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+)
+
+func TestIsPlatformPackage(t *testing.T) {
+	tests := []struct {
+		pkg  string
+		want bool
+	}{
+		{"php", true},
+		{"php-64bit", true},
+		{"hhvm", true},
+		{"composer-plugin-api", true},
+		{"composer-runtime-api", true},
+		{"ext-mbstring", true},
+		{"lib-openssl", true},
+		{"monolog/monolog", false},
+		{"symfony/console", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pkg, func(t *testing.T) {
+			if got := isPlatformPackage(tt.pkg); got != tt.want {
+				t.Fatalf("isPlatformPackage(%q) = %v, want %v", tt.pkg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackageID(t *testing.T) {
+	tests := []struct {
+		pkg  string
+		want string
+	}{
+		{"monolog/monolog", "monolog/monolog"},
+		{"ext-json", platformPrefix + "ext-json"},
+		{"php", platformPrefix + "php"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pkg, func(t *testing.T) {
+			if got := packageID(tt.pkg); got != tt.want {
+				t.Fatalf("packageID(%q) = %q, want %q", tt.pkg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeLicense(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", raw: "", want: ""},
+		{name: "single", raw: `"MIT"`, want: "MIT"},
+		{name: "multiple", raw: `["MIT", "Apache-2.0"]`, want: "MIT OR Apache-2.0"},
+		{name: "invalid", raw: `123`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeLicense([]byte(tt.raw))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeLicense(%q) error = nil, want error", tt.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeLicense(%q) error = %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Fatalf("decodeLicense(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	composerJSON := `{
+		"name": "my/project",
+		"require": {
+			"php": ">=7.4",
+			"monolog/monolog": "2.0.0"
+		},
+		"require-dev": {
+			"phpunit/phpunit": "9.5.0"
+		}
+	}`
+
+	libs, deps, err := NewParser().Parse(bytes.NewReader([]byte(composerJSON)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(deps) != 0 {
+		t.Fatalf("Parse() deps = %v, want empty", deps)
+	}
+	if len(libs) != 3 {
+		t.Fatalf("Parse() libs = %v, want 3 entries", libs)
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	composerJSON := `{
+		"name": "my/project",
+		"version": "1.2.3",
+		"license": ["MIT", "Apache-2.0"]
+	}`
+
+	fsys := fstest.MapFS{
+		"composer.json": {Data: []byte(composerJSON)},
+	}
+
+	manifest, err := NewParser().(*Parser).ParseManifest(fsys, "composer.json")
+	if err != nil {
+		t.Fatalf("ParseManifest() error = %v", err)
+	}
+
+	if got, want := manifest.PackageID(), "my/project@1.2.3"; got != want {
+		t.Fatalf("PackageID() = %q, want %q", got, want)
+	}
+	if got, want := manifest.DeclaredLicense(), "MIT OR Apache-2.0"; got != want {
+		t.Fatalf("DeclaredLicense() = %q, want %q", got, want)
+	}
+}